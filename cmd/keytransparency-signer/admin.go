@@ -0,0 +1,75 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// readyDomains tracks, per domain, whether this process currently holds
+// the domain's leader election (or leader election is disabled) and has
+// finished startup. /readyz reports ready once every registered domain is.
+var (
+	readyMu      sync.Mutex
+	readyDomains = make(map[string]bool)
+)
+
+func setReady(domainID string, v bool) {
+	readyMu.Lock()
+	defer readyMu.Unlock()
+	readyDomains[domainID] = v
+}
+
+func isReady() bool {
+	readyMu.Lock()
+	defer readyMu.Unlock()
+	for _, ok := range readyDomains {
+		if !ok {
+			return false
+		}
+	}
+	return len(readyDomains) > 0
+}
+
+// newAdminServer builds the admin HTTP server exposing health, readiness,
+// Prometheus metrics, and pprof profiles. It is not started until the
+// caller calls Serve/ListenAndServe on the result.
+func newAdminServer() *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !isReady() {
+			http.Error(w, "not leader", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return &http.Server{Addr: *adminAddr, Handler: mux}
+}
@@ -17,23 +17,35 @@ package main
 import (
 	"database/sql"
 	"flag"
-	"io/ioutil"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
+	coreappender "github.com/google/keytransparency/core/appender"
 	"github.com/google/keytransparency/core/crypto/signatures"
 	"github.com/google/keytransparency/core/crypto/signatures/factory"
 	"github.com/google/keytransparency/core/mutator/entry"
+	"github.com/google/keytransparency/core/notifier"
+	corequeue "github.com/google/keytransparency/core/queue"
 	"github.com/google/keytransparency/core/signer"
-	"github.com/google/keytransparency/impl/etcd/queue"
-	"github.com/google/keytransparency/impl/sql/appender"
+	"github.com/google/keytransparency/core/signer/manager"
+	etcdqueue "github.com/google/keytransparency/impl/etcd/queue"
+	"github.com/google/keytransparency/impl/gcp/pubsub"
+	_ "github.com/google/keytransparency/impl/mem/queue"
+	objectappender "github.com/google/keytransparency/impl/objectstore/appender"
+	sqlappender "github.com/google/keytransparency/impl/sql/appender"
 	"github.com/google/keytransparency/impl/sql/engine"
+	_ "github.com/google/keytransparency/impl/sql/queue"
 	"github.com/google/keytransparency/impl/sql/sqlhist"
 	"github.com/google/keytransparency/impl/transaction"
 
 	"github.com/coreos/etcd/clientv3"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
 )
 
@@ -43,9 +55,63 @@ var (
 	epochDuration = flag.Uint("period", 60, "Seconds between epoch creation")
 	mapID         = flag.String("domain", "example.com", "Distinguished name for this key server")
 	mapLogURL     = flag.String("maplog", "", "URL of CT server for Signed Map Heads")
-	signingKey    = flag.String("key", "", "Path to private key PEM for STH signing")
+	signingKey    = flag.String("key", "", "URI of the STH signing key: a bare path or file:// path, or gcpkms://, awskms://, or hashivault:// for a KMS-backed key")
+	configPath    = flag.String("config", "", "Path to a YAML/JSON multi-domain config (see core/signer/manager). Overrides --domain, --period, --key, and --maplog")
+	queueBackend  = flag.String("queue", "etcd", "Mutation queue backend: etcd, sql, or mem")
+	sthAppender   = flag.String("sth-appender", "sql", "Append-only store for STHs and mutations: sql, gcs, or s3")
+	sthBucket     = flag.String("sth-bucket", "", "GCS or S3 bucket name, required when --sth-appender is gcs or s3")
+	sthPrefix     = flag.String("sth-prefix", "keytransparency", "Object key prefix under --sth-bucket")
+	adminAddr     = flag.String("admin-addr", ":8081", "Address for the /healthz, /readyz, /metrics, and /debug/pprof admin server")
+	electionKey   = flag.String("election-key", "", "etcd key to campaign on for leader election; requires etcd to be reachable. Disabled if empty")
+
+	pubsubProvider = flag.String("pubsub-provider", "", "Message bus for epoch/mutation notifications: \"\" (disabled) or \"gcp\"")
+	pubsubProject  = flag.String("pubsub-project", "", "GCP project id for the pubsub-provider=gcp notifier")
+	topicEpochs    = flag.String("pubsub-topic-epochs", "keytransparency-epochs", "Topic for new-epoch notifications")
+	topicMutations = flag.String("pubsub-topic-mutations", "keytransparency-mutations", "Topic for new-mutation notifications")
 )
 
+var publishFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "keytransparency_notifier_publish_failures",
+	Help: "Number of notifier.Publisher.Publish calls that failed, by domain and topic.",
+}, []string{"domain", "topic"})
+
+func init() {
+	prometheus.MustRegister(publishFailures)
+}
+
+// publish sends event to topic on pub, if notifications are enabled. A
+// broker outage must never block signing, so a publish failure is only
+// logged and counted in publishFailures rather than returned to the
+// caller.
+func publish(ctx context.Context, pub notifier.Publisher, domainID, topic string, event *notifier.Event) {
+	if pub == nil {
+		return
+	}
+	if err := pub.Publish(ctx, topic, event); err != nil {
+		log.Printf("domain %v: notifier.Publish(%v): %v", domainID, topic, err)
+		publishFailures.WithLabelValues(domainID, topic).Inc()
+	}
+}
+
+// openPublisher returns the configured notifier.Publisher, or nil if
+// notifications are disabled. A broker outage must never block signing, so
+// callers treat publish errors as non-fatal and only bump publishFailures.
+func openPublisher(ctx context.Context) notifier.Publisher {
+	switch *pubsubProvider {
+	case "":
+		return nil
+	case "gcp":
+		pub, err := pubsub.New(ctx, *pubsubProject)
+		if err != nil {
+			log.Fatalf("Failed to create GCP pubsub notifier: %v", err)
+		}
+		return pub
+	default:
+		log.Fatalf("Unknown --pubsub-provider %q", *pubsubProvider)
+		return nil
+	}
+}
+
 func openDB() *sql.DB {
 	db, err := sql.Open(engine.DriverName, *serverDBPath)
 	if err != nil {
@@ -68,53 +134,228 @@ func openEtcd() *clientv3.Client {
 	return cli
 }
 
-func openPrivateKey() signatures.Signer {
-	pem, err := ioutil.ReadFile(*signingKey)
-	if err != nil {
-		log.Fatalf("Failed to read file %v: %v", *signingKey, err)
+// openAppender constructs the append-only store for name (either "sths" or
+// "mutations"), using db when --sth-appender is sql and object storage
+// otherwise. db is unused by the gcs and s3 stores. name only selects the
+// object-storage key prefix; the sql store is not given name.
+func openAppender(ctx context.Context, db *sql.DB, mapID, mapLogURL, name string) (coreappender.Appender, error) {
+	switch *sthAppender {
+	case "sql":
+		return sqlappender.New(ctx, db, mapID, mapLogURL, nil)
+	case "gcs":
+		return objectappender.NewGCS(ctx, *sthBucket, *sthPrefix+"/"+name, mapID)
+	case "s3":
+		return objectappender.NewS3(ctx, *sthBucket, *sthPrefix+"/"+name, mapID)
+	default:
+		return nil, fmt.Errorf("unknown --sth-appender %q", *sthAppender)
 	}
-	sig, err := factory.NewSignerFromPEM(pem)
-	if err != nil {
-		log.Fatalf("Failed to create signer: %v", err)
+}
+
+func openPrivateKey(ctx context.Context, keyURI string) (signatures.Signer, error) {
+	return factory.NewSignerFromURI(ctx, keyURI)
+}
+
+// singleDomainConfig builds the one-domain manager.Config implied by the
+// legacy --domain, --period, --key, and --maplog flags, for operators who
+// don't need --config.
+func singleDomainConfig() *manager.Config {
+	return &manager.Config{
+		Domains: []manager.Domain{{
+			DomainID:           *mapID,
+			MapID:              *mapID,
+			EpochPeriodSeconds: int(*epochDuration),
+			SigningKey:         *signingKey,
+			MapLogURL:          *mapLogURL,
+		}},
 	}
-	return sig
 }
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	flag.Parse()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := singleDomainConfig()
+	if *configPath != "" {
+		var err error
+		cfg, err = manager.LoadConfig(*configPath)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
 	sqldb := openDB()
 	defer sqldb.Close()
-	etcdCli := openEtcd()
-	defer etcdCli.Close()
-	factory := transaction.NewFactory(sqldb, etcdCli)
 
-	// Create signer helper objects.
-	queue := queue.New(context.Background(), etcdCli, *mapID, factory)
-	tree, err := sqlhist.New(context.Background(), sqldb, *mapID, factory)
-	if err != nil {
-		log.Fatalf("Failed to create SQL history: %v", err)
+	var etcdCli *clientv3.Client
+	if *queueBackend == "etcd" || *electionKey != "" {
+		etcdCli = openEtcd()
+		defer etcdCli.Close()
 	}
-	mutator := entry.New()
-	sths, err := appender.New(context.Background(), sqldb, *mapID, *mapLogURL, nil)
-	if err != nil {
-		log.Fatalf("Failed to create STH appender: %v", err)
+	// Registered here, rather than from an init function, so the "etcd"
+	// backend shares the single etcdCli opened above instead of every
+	// domain dialing its own (and leaking it).
+	corequeue.Register("etcd", func(ctx context.Context, domainID string, factory *transaction.Factory) (corequeue.Queue, error) {
+		return etcdqueue.New(ctx, etcdCli, domainID, factory), nil
+	})
+	factory := transaction.NewFactory(sqldb, etcdCli)
+
+	pub := openPublisher(ctx)
+	if pub != nil {
+		defer pub.Close()
 	}
-	mutations, err := appender.New(context.Background(), nil, *mapID, *mapLogURL, nil)
-	if err != nil {
-		log.Fatalf("Failed to create mutation appender: %v", err)
+
+	admin := newAdminServer()
+	go func() {
+		if err := admin.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("admin server: %v", err)
+		}
+	}()
+
+	log.Printf("Signer started, serving %d domain(s).", len(cfg.Domains))
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		log.Printf("Shutting down...")
+		cancel()
+	}()
+
+	manager.Run(ctx, cfg, runDomain(sqldb, etcdCli, factory, pub))
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := admin.Shutdown(shutdownCtx); err != nil {
+		log.Printf("admin.Shutdown(): %v", err)
 	}
+}
 
-	signer := signer.New(*mapID, queue, tree, mutator, sths, mutations, openPrivateKey())
-	if _, err := queue.StartReceiving(signer.ProcessMutation, signer.CreateEpoch); err != nil {
-		log.Fatalf("failed to start queue receiver: %v", err)
+// runSigningLoop calls procCreateEpoch once per period until ctx is
+// canceled. It lives here, rather than in core/signer, because only a
+// context-aware loop lets stopLeading halt epoch creation promptly when
+// this replica loses (or never wins) leader election; core/signer's own
+// StartSigning takes no context and runs until the process exits.
+func runSigningLoop(ctx context.Context, domainID string, period time.Duration, procCreateEpoch corequeue.ProcessCreateEpochFunc) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := procCreateEpoch(); err != nil {
+				log.Printf("domain %v: CreateEpoch(): %v", domainID, err)
+			}
+		}
 	}
-	go signer.StartSigning(time.Duration(*epochDuration) * time.Second)
+}
+
+// runDomain returns a manager.DomainRunner that builds one domain's signer
+// out of the shared SQL/etcd connections and runs it, under leader election
+// if --election-key is set, until ctx is canceled.
+func runDomain(sqldb *sql.DB, etcdCli *clientv3.Client, factory *transaction.Factory, pub notifier.Publisher) manager.DomainRunner {
+	return func(ctx context.Context, d manager.Domain) {
+		queue, err := corequeue.New(ctx, *queueBackend, d.DomainID, factory)
+		if err != nil {
+			log.Printf("domain %v: creating %v queue: %v", d.DomainID, *queueBackend, err)
+			return
+		}
+		tree, err := sqlhist.New(ctx, sqldb, d.MapID, factory)
+		if err != nil {
+			log.Printf("domain %v: creating SQL history: %v", d.DomainID, err)
+			return
+		}
+		mutator := entry.New()
+		sths, err := openAppender(ctx, sqldb, d.MapID, d.MapLogURL, "sths")
+		if err != nil {
+			log.Printf("domain %v: creating STH appender: %v", d.DomainID, err)
+			return
+		}
+		mutations, err := openAppender(ctx, sqldb, d.MapID, d.MapLogURL, "mutations")
+		if err != nil {
+			log.Printf("domain %v: creating mutation appender: %v", d.DomainID, err)
+			return
+		}
+		key, err := openPrivateKey(ctx, d.SigningKey)
+		if err != nil {
+			log.Printf("domain %v: loading signing key: %v", d.DomainID, err)
+			return
+		}
 
-	log.Printf("Signer started.")
+		s := signer.New(d.MapID, queue, tree, mutator, sths, mutations, key)
 
-	var wg sync.WaitGroup
-	wg.Add(1)
-	wg.Wait()
+		// procMutation and procCreateEpoch wrap the signer's own callbacks
+		// so that a pubsub notification goes out for every mutation the
+		// signer accepts and every epoch it creates; core/signer itself
+		// knows nothing about the notifier.
+		procMutation := func(subject, mutation []byte) error {
+			if err := s.ProcessMutation(mutation); err != nil {
+				return err
+			}
+			subjectHash := notifier.SubjectHash(subject)
+			// SubjectHash is a plain sha256; hashing the mutation too keeps
+			// two mutations for the same subject from sharing an ID.
+			mutationHash := notifier.SubjectHash(mutation)
+			publish(ctx, pub, d.DomainID, *topicMutations, &notifier.Event{
+				ID:          fmt.Sprintf("%s-mutation-%x-%x", d.DomainID, subjectHash, mutationHash),
+				Type:        notifier.EventNewMutation,
+				Domain:      d.DomainID,
+				Data:        mutation,
+				SubjectHash: subjectHash,
+			})
+			return nil
+		}
+		procCreateEpoch := func() error {
+			if err := s.CreateEpoch(); err != nil {
+				return err
+			}
+			epoch, smh, err := sths.Latest(ctx)
+			if err != nil {
+				log.Printf("domain %v: sths.Latest() after CreateEpoch(): %v", d.DomainID, err)
+				return nil
+			}
+			publish(ctx, pub, d.DomainID, *topicEpochs, &notifier.Event{
+				ID:     fmt.Sprintf("%s-epoch-%d", d.DomainID, epoch),
+				Type:   notifier.EventNewEpoch,
+				Domain: d.DomainID,
+				Epoch:  epoch,
+				Data:   smh,
+			})
+			return nil
+		}
+
+		receiver, err := queue.StartReceiving(procMutation, procCreateEpoch)
+		if err != nil {
+			log.Printf("domain %v: starting queue receiver: %v", d.DomainID, err)
+			return
+		}
+		defer func() {
+			if err := receiver.Close(); err != nil {
+				log.Printf("domain %v: receiver.Close(): %v", d.DomainID, err)
+			}
+		}()
+
+		var stopSigning context.CancelFunc
+		becomeLeader := func() {
+			var signingCtx context.Context
+			signingCtx, stopSigning = context.WithCancel(ctx)
+			go runSigningLoop(signingCtx, d.DomainID, d.EpochPeriod(), procCreateEpoch)
+			setReady(d.DomainID, true)
+		}
+		stopLeading := func() {
+			setReady(d.DomainID, false)
+			if stopSigning != nil {
+				stopSigning()
+			}
+		}
+
+		electKey := ""
+		if *electionKey != "" {
+			electKey = *electionKey + "/" + d.DomainID
+		}
+		runAsLeader(ctx, etcdCli, electKey, becomeLeader, stopLeading)
+	}
 }
@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"golang.org/x/net/context"
+)
+
+// runAsLeader blocks until ctx is canceled, calling becomeLeader every time
+// this process wins the election on electionKey and stopLeading every time
+// it loses it (including when a previous term ends because ctx was
+// canceled). If cli is nil or electionKey is empty, leader election is
+// disabled and becomeLeader is called once immediately, since every replica
+// is then its own "leader".
+func runAsLeader(ctx context.Context, cli *clientv3.Client, electionKey string, becomeLeader, stopLeading func()) {
+	if cli == nil || electionKey == "" {
+		becomeLeader()
+		<-ctx.Done()
+		stopLeading()
+		return
+	}
+
+	for ctx.Err() == nil {
+		session, err := concurrency.NewSession(cli, concurrency.WithTTL(15))
+		if err != nil {
+			log.Printf("concurrency.NewSession(): %v; retrying", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		election := concurrency.NewElection(session, electionKey)
+
+		candidate, err := os.Hostname()
+		if err != nil {
+			candidate = "unknown"
+		}
+		if err := election.Campaign(ctx, candidate); err != nil {
+			session.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("election.Campaign(): %v; retrying", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		log.Printf("Elected leader for election key %q", electionKey)
+		becomeLeader()
+
+		select {
+		case <-ctx.Done():
+			stopLeading()
+			session.Close()
+			return
+		case <-session.Done():
+			log.Printf("Lost leadership for election key %q; campaigning for the next term", electionKey)
+			stopLeading()
+		}
+	}
+}
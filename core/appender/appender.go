@@ -0,0 +1,31 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package appender defines the append-only log abstraction the signer uses
+// to persist Signed Map Heads and mutation batches. impl/sql/appender and
+// impl/objectstore/appender both implement Appender.
+package appender
+
+import "golang.org/x/net/context"
+
+// Appender stores epoch-indexed, immutable records for a domain. Append
+// must fail if epoch has already been written, since the whole point of the
+// structure is an append-only, tamper-evident log.
+type Appender interface {
+	// Append writes data for epoch. It returns an error if epoch already
+	// has a value, preserving append-only semantics.
+	Append(ctx context.Context, epoch int64, data []byte) error
+	// Latest returns the highest epoch written and its data.
+	Latest(ctx context.Context) (epoch int64, data []byte, err error)
+}
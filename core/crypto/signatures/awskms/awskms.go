@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package awskms implements signatures.Signer against an AWS KMS
+// asymmetric signing key, so the STH private key never leaves KMS.
+package awskms
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/google/keytransparency/core/crypto/signatures"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+
+	"golang.org/x/net/context"
+)
+
+// Signer signs digests by calling KMS's Sign API for keyID.
+type Signer struct {
+	client    *kms.KMS
+	keyID     string
+	publicKey crypto.PublicKey
+}
+
+// NewSigner connects to AWS KMS in region and caches keyID's public key.
+func NewSigner(ctx context.Context, keyID, region string) (signatures.Signer, error) {
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(region)})
+	if err != nil {
+		return nil, fmt.Errorf("awskms: session.NewSession(): %v", err)
+	}
+	client := kms.New(sess)
+
+	resp, err := client.GetPublicKeyWithContext(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("awskms: GetPublicKey(%v): %v", keyID, err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("awskms: ParsePKIXPublicKey(): %v", err)
+	}
+
+	return &Signer{client: client, keyID: keyID, publicKey: pub}, nil
+}
+
+// Sign implements signatures.Signer by asking KMS to sign digest.
+func (s *Signer) Sign(digest []byte) ([]byte, error) {
+	resp, err := s.client.SignWithContext(context.Background(), &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(kms.SigningAlgorithmSpecEcdsaSha256),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("awskms: Sign(): %v", err)
+	}
+	return resp.Signature, nil
+}
+
+// PublicKey implements signatures.Signer.
+func (s *Signer) PublicKey() crypto.PublicKey {
+	return s.publicKey
+}
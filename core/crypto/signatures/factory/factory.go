@@ -0,0 +1,54 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package factory constructs signatures.Signer instances from a PEM file
+// or, via NewSignerFromURI, from a variety of key storage backends.
+package factory
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/google/keytransparency/core/crypto/signatures"
+)
+
+type pemSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewSignerFromPEM parses an ECDSA private key PEM block and returns a
+// signatures.Signer backed by it.
+func NewSignerFromPEM(pemBytes []byte) (signatures.Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("factory: no PEM block found")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("factory: ParseECPrivateKey(): %v", err)
+	}
+	return &pemSigner{key: key}, nil
+}
+
+func (s *pemSigner) Sign(digest []byte) ([]byte, error) {
+	return ecdsa.SignASN1(rand.Reader, s.key, digest)
+}
+
+func (s *pemSigner) PublicKey() crypto.PublicKey {
+	return &s.key.PublicKey
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package factory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/google/keytransparency/core/crypto/signatures"
+	"github.com/google/keytransparency/core/crypto/signatures/awskms"
+	"github.com/google/keytransparency/core/crypto/signatures/gcpkms"
+	"github.com/google/keytransparency/core/crypto/signatures/hashivault"
+
+	"golang.org/x/net/context"
+)
+
+// NewSignerFromURI constructs a signatures.Signer from uri, so that
+// private key material never has to touch the signer host. The scheme
+// selects where the key lives:
+//
+//	file://path/to/key.pem                                   local PEM file
+//	gcpkms://projects/P/locations/L/keyRings/R/cryptoKeys/K/cryptoKeyVersions/N
+//	awskms://KEY-ID?region=REGION                            key ID or alias/NAME
+//	hashivault://VAULT_ADDR/transit/keys/KEY_NAME
+//
+// A key ARN is not accepted after awskms://: its colons make url.Parse
+// reject the URI.
+func NewSignerFromURI(ctx context.Context, uri string) (signatures.Signer, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("factory: parsing key URI %q: %v", uri, err)
+	}
+
+	switch u.Scheme {
+	case "file", "":
+		// u.Host+u.Path, not just u.Path: url.Parse puts everything
+		// before the next "/" into Host, so a relative
+		// "file://path/to/key.pem" would otherwise silently lose its
+		// "path" segment.
+		path := u.Host + u.Path
+		if u.Scheme == "" {
+			path = uri
+		}
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("factory: reading %v: %v", path, err)
+		}
+		return NewSignerFromPEM(pem)
+	case "gcpkms":
+		return gcpkms.NewSigner(ctx, u.Host+u.Path)
+	case "awskms":
+		return awskms.NewSigner(ctx, u.Host+u.Path, u.Query().Get("region"))
+	case "hashivault":
+		return hashivault.NewSigner(ctx, "https://"+u.Host, u.Path)
+	default:
+		return nil, fmt.Errorf("factory: unsupported key URI scheme %q", u.Scheme)
+	}
+}
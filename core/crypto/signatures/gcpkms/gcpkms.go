@@ -0,0 +1,83 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gcpkms implements signatures.Signer against a Cloud KMS
+// asymmetric signing key version, so the STH private key never leaves
+// Google's KMS.
+package gcpkms
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"github.com/google/keytransparency/core/crypto/signatures"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+
+	"golang.org/x/net/context"
+)
+
+// Signer signs digests by calling Cloud KMS's AsymmetricSign RPC for
+// cryptoKeyVersion, a resource name of the form
+// "projects/P/locations/L/keyRings/R/cryptoKeys/K/cryptoKeyVersions/N".
+type Signer struct {
+	client           *kms.KeyManagementClient
+	cryptoKeyVersion string
+	publicKey        crypto.PublicKey
+}
+
+// NewSigner connects to Cloud KMS and caches the public key for
+// cryptoKeyVersion.
+func NewSigner(ctx context.Context, cryptoKeyVersion string) (signatures.Signer, error) {
+	client, err := kms.NewKeyManagementClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: NewKeyManagementClient(): %v", err)
+	}
+
+	resp, err := client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: cryptoKeyVersion})
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: GetPublicKey(%v): %v", cryptoKeyVersion, err)
+	}
+	block, _ := pem.Decode([]byte(resp.Pem))
+	if block == nil {
+		return nil, fmt.Errorf("gcpkms: no PEM block in public key response")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: ParsePKIXPublicKey(): %v", err)
+	}
+
+	return &Signer{client: client, cryptoKeyVersion: cryptoKeyVersion, publicKey: pub}, nil
+}
+
+// Sign implements signatures.Signer by asking Cloud KMS to sign digest.
+func (s *Signer) Sign(digest []byte) ([]byte, error) {
+	resp, err := s.client.AsymmetricSign(context.Background(), &kmspb.AsymmetricSignRequest{
+		Name: s.cryptoKeyVersion,
+		Digest: &kmspb.Digest{
+			Digest: &kmspb.Digest_Sha256{Sha256: digest},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: AsymmetricSign(): %v", err)
+	}
+	return resp.Signature, nil
+}
+
+// PublicKey implements signatures.Signer.
+func (s *Signer) PublicKey() crypto.PublicKey {
+	return s.publicKey
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hashivault implements signatures.Signer against a HashiCorp
+// Vault transit secrets engine key, so the STH private key never leaves
+// Vault.
+package hashivault
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/google/keytransparency/core/crypto/signatures"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"golang.org/x/net/context"
+)
+
+// Signer signs digests through Vault's transit/sign/<keyName> endpoint.
+type Signer struct {
+	client    *vaultapi.Logical
+	keyPath   string
+	keyType   string
+	publicKey crypto.PublicKey
+}
+
+// NewSigner connects to the Vault at addr and caches the public key for
+// the transit key named by keyPath (e.g. "/transit/keys/sth-signing-key").
+func NewSigner(ctx context.Context, addr, keyPath string) (signatures.Signer, error) {
+	cfg := vaultapi.DefaultConfig()
+	cfg.Address = addr
+	client, err := vaultapi.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("hashivault: NewClient(): %v", err)
+	}
+
+	secret, err := client.Logical().Read(strings.TrimPrefix(keyPath, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("hashivault: reading %v: %v", keyPath, err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("hashivault: %v: no such key", keyPath)
+	}
+	keyType, _ := secret.Data["type"].(string)
+	keys, _ := secret.Data["keys"].(map[string]interface{})
+	// The Vault client decodes JSON numbers as json.Number, so the
+	// version is formatted rather than asserted to a Go integer type.
+	version, ok := keys[fmt.Sprint(secret.Data["latest_version"])].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("hashivault: %v: malformed key material", keyPath)
+	}
+	pemStr, ok := version["public_key"].(string)
+	if !ok {
+		return nil, fmt.Errorf("hashivault: %v: no public_key in key material", keyPath)
+	}
+	block, _ := pem.Decode([]byte(pemStr))
+	if block == nil {
+		return nil, fmt.Errorf("hashivault: %v: no PEM block in public_key", keyPath)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("hashivault: ParsePKIXPublicKey(): %v", err)
+	}
+
+	return &Signer{client: client.Logical(), keyPath: keyPath, keyType: keyType, publicKey: pub}, nil
+}
+
+// Sign implements signatures.Signer by asking Vault's transit engine to
+// sign digest. The signature parameters sent depend on the transit key's
+// type: RSA keys need a PKCS#1v1.5 signature_algorithm, while EC keys (the
+// only other type this signer supports) need none.
+func (s *Signer) Sign(digest []byte) ([]byte, error) {
+	signPath := strings.Replace(strings.TrimPrefix(s.keyPath, "/"), "keys/", "sign/", 1)
+	data := map[string]interface{}{
+		"input":     base64.StdEncoding.EncodeToString(digest),
+		"prehashed": true,
+	}
+	if strings.HasPrefix(s.keyType, "rsa") {
+		data["signature_algorithm"] = "pkcs1v15"
+	}
+	secret, err := s.client.Write(signPath, data)
+	if err != nil {
+		return nil, fmt.Errorf("hashivault: sign: %v", err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("hashivault: sign: empty response")
+	}
+	sig, ok := secret.Data["signature"].(string)
+	if !ok {
+		return nil, fmt.Errorf("hashivault: sign: no signature in response")
+	}
+	// Vault returns "vault:v<version>:<base64 signature>".
+	parts := strings.SplitN(sig, ":", 3)
+	der, err := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+	if err != nil {
+		return nil, fmt.Errorf("hashivault: decoding signature: %v", err)
+	}
+	return der, nil
+}
+
+// PublicKey implements signatures.Signer.
+func (s *Signer) PublicKey() crypto.PublicKey {
+	return s.publicKey
+}
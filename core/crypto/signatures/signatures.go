@@ -0,0 +1,29 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signatures defines the STH-signing abstraction the signer uses,
+// so that the private key can live on local disk, in a KMS, or in Vault
+// without changing any caller.
+package signatures
+
+import "crypto"
+
+// Signer signs digests on behalf of a single private key.
+type Signer interface {
+	// Sign returns a DER-encoded signature over digest.
+	Sign(digest []byte) ([]byte, error)
+	// PublicKey returns the public key matching the signing key, for
+	// callers that need to publish or verify against it.
+	PublicKey() crypto.PublicKey
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notifier publishes signer events (new epochs and accepted
+// mutations) to an external message bus so that monitors and auditors can
+// react to them without polling the map or the append-only logs.
+package notifier
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/net/context"
+)
+
+// Event types published by the signer.
+const (
+	// EventNewEpoch is published whenever signer.CreateEpoch produces a new
+	// Signed Map Head.
+	EventNewEpoch = "keytransparency.new_epoch.v1"
+	// EventNewMutation is published whenever ProcessMutation accepts a
+	// mutation into the map.
+	EventNewMutation = "keytransparency.new_mutation.v1"
+)
+
+// Event is the envelope published to the message bus for every new epoch or
+// mutation. Data holds the serialized SignedMapHead or the mutation bytes.
+type Event struct {
+	// ID uniquely identifies this event for broker-side dedup.
+	ID string
+	// Type is one of the Event* constants above.
+	Type string
+	// Domain is the map's distinguished name.
+	Domain string
+	// Epoch is the map revision of a new-epoch event. It is zero for
+	// mutation events.
+	Epoch int64
+	// Data is the serialized SignedMapHead for a new-epoch event, or the
+	// mutation bytes as delivered by the queue for a mutation event.
+	Data []byte
+	// SubjectHash lets subscribers filter for a single subject without
+	// decoding Data.
+	SubjectHash []byte
+}
+
+// SubjectHash returns the sha256 digest of subject, suitable for use as
+// Event.SubjectHash.
+func SubjectHash(subject []byte) []byte {
+	h := sha256.Sum256(subject)
+	return h[:]
+}
+
+// Publisher publishes events to a topic on a message bus. Implementations
+// must be safe for concurrent use.
+type Publisher interface {
+	// Publish sends event to topic. Implementations should set any
+	// available per-message ordering key to event.Domain so that
+	// subscribers observe epochs in order.
+	Publish(ctx context.Context, topic string, event *Event) error
+	// Close releases resources held by the publisher.
+	Close() error
+}
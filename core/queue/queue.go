@@ -0,0 +1,86 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queue defines the mutation-queue abstraction the signer uses to
+// receive mutations and epoch-advance ticks, and a registry of named
+// backend Factories so that main does not have to hard-code a single queue
+// implementation (etcd, SQL, or in-memory).
+package queue
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/keytransparency/impl/transaction"
+
+	"golang.org/x/net/context"
+)
+
+// ProcessMutationFunc is invoked for every mutation the queue delivers.
+// subject is the mutation's map index, supplied by whatever enqueued the
+// mutation, so that callers can key off the subject (e.g. for the
+// notifier's per-subject filtering) without decoding the opaque mutation
+// payload.
+type ProcessMutationFunc func(subject, mutation []byte) error
+
+// ProcessCreateEpochFunc is invoked whenever the queue signals that it is
+// time to advance the epoch.
+type ProcessCreateEpochFunc func() error
+
+// Receiver is returned by StartReceiving and stops delivery when closed.
+type Receiver interface {
+	Close() error
+}
+
+// Queue delivers mutations and epoch-advance ticks to the signer.
+type Queue interface {
+	// StartReceiving begins delivering events to procMutation and
+	// procCreateEpoch on background goroutines until the returned Receiver
+	// is closed.
+	StartReceiving(procMutation ProcessMutationFunc, procCreateEpoch ProcessCreateEpochFunc) (Receiver, error)
+}
+
+// Factory constructs a Queue for domainID, sharing factory's SQL/etcd
+// connections.
+type Factory func(ctx context.Context, domainID string, factory *transaction.Factory) (Queue, error)
+
+var (
+	mu       sync.Mutex
+	backends = make(map[string]Factory)
+)
+
+// Register associates name with f so that New(ctx, name, ...) can
+// construct a Queue of that backend. Backend packages usually call Register
+// from an init function; a backend that needs a client owned by main can be
+// registered by main instead. Register panics if name is already registered, which
+// would indicate two backend packages picked the same name.
+func Register(name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("queue: backend %q already registered", name))
+	}
+	backends[name] = f
+}
+
+// New constructs the named backend's Queue for domainID.
+func New(ctx context.Context, name, domainID string, factory *transaction.Factory) (Queue, error) {
+	mu.Lock()
+	f, ok := backends[name]
+	mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("queue: unknown backend %q", name)
+	}
+	return f(ctx, domainID, factory)
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package manager runs one signer.Signer per domain out of a single
+// process, so that a fleet of signer replicas can serve many domains on
+// shared SQL/etcd connections instead of one process per domain.
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Domain describes one domain's signer configuration.
+type Domain struct {
+	// DomainID is the distinguished name for this key server, shared with
+	// the rest of the stack (e.g. the mutation queue and notifier topics).
+	DomainID string `json:"domainId" yaml:"domainId"`
+	// MapID is the backing map's identifier.
+	MapID string `json:"mapId" yaml:"mapId"`
+	// EpochPeriodSeconds is how often, in seconds, this domain advances
+	// its epoch. JSON and YAML have no duration type, so this is a plain
+	// integer rather than a time.Duration (which would decode as
+	// nanoseconds and silently misconfigure the epoch ticker).
+	EpochPeriodSeconds int `json:"epochPeriodSeconds" yaml:"epochPeriodSeconds"`
+	// SigningKey is the URI or path to this domain's STH signing key.
+	SigningKey string `json:"signingKey" yaml:"signingKey"`
+	// MapLogURL is the URL of the CT server recording this domain's SMHs.
+	MapLogURL string `json:"mapLogUrl" yaml:"mapLogUrl"`
+}
+
+// EpochPeriod returns how often d should advance its epoch, as a
+// time.Duration.
+func (d Domain) EpochPeriod() time.Duration {
+	return time.Duration(d.EpochPeriodSeconds) * time.Second
+}
+
+// Config lists every domain a signer process should serve.
+type Config struct {
+	Domains []Domain `json:"domains" yaml:"domains"`
+}
+
+// LoadConfig reads a Config from path, parsing it as YAML or JSON based on
+// the file extension (.json, .yaml, .yml).
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("manager: reading %v: %v", path, err)
+	}
+
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(b, &cfg)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &cfg)
+	default:
+		return nil, fmt.Errorf("manager: %v: unrecognized config extension, want .json, .yaml, or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("manager: parsing %v: %v", path, err)
+	}
+	if len(cfg.Domains) == 0 {
+		return nil, fmt.Errorf("manager: %v: no domains configured", path)
+	}
+	for _, d := range cfg.Domains {
+		if d.EpochPeriodSeconds <= 0 {
+			return nil, fmt.Errorf("manager: %v: domain %v: epochPeriodSeconds must be > 0", path, d.DomainID)
+		}
+	}
+	return &cfg, nil
+}
+
+// DomainRunner starts serving d and blocks until ctx is canceled, releasing
+// d's resources before returning.
+type DomainRunner func(ctx context.Context, d Domain)
+
+// Run starts run for every domain in cfg concurrently and blocks until all
+// of them have returned, which happens once ctx is canceled and each
+// domain finishes shutting down.
+func Run(ctx context.Context, cfg *Config, run DomainRunner) {
+	var wg sync.WaitGroup
+	for _, d := range cfg.Domains {
+		wg.Add(1)
+		go func(d Domain) {
+			defer wg.Done()
+			run(ctx, d)
+		}(d)
+	}
+	wg.Wait()
+}
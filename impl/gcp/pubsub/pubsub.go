@@ -0,0 +1,86 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pubsub implements notifier.Publisher on top of Google Cloud
+// Pub/Sub.
+package pubsub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/keytransparency/core/notifier"
+
+	"cloud.google.com/go/pubsub"
+	"golang.org/x/net/context"
+)
+
+// Publisher publishes notifier.Events to GCP Pub/Sub topics, setting the
+// ordering key to the event's domain so that a single subscriber observes a
+// domain's epochs in order.
+type Publisher struct {
+	client *pubsub.Client
+
+	mu     sync.Mutex
+	topics map[string]*pubsub.Topic
+}
+
+// New returns a Publisher backed by the Pub/Sub project projectID.
+func New(ctx context.Context, projectID string) (*Publisher, error) {
+	client, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("pubsub.NewClient(%v): %v", projectID, err)
+	}
+	return &Publisher{
+		client: client,
+		topics: make(map[string]*pubsub.Topic),
+	}, nil
+}
+
+func (p *Publisher) topic(name string) *pubsub.Topic {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.topics[name]; ok {
+		return t
+	}
+	t := p.client.Topic(name)
+	t.EnableMessageOrdering = true
+	p.topics[name] = t
+	return t
+}
+
+// Publish implements notifier.Publisher.
+func (p *Publisher) Publish(ctx context.Context, topic string, event *notifier.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("json.Marshal(event): %v", err)
+	}
+	result := p.topic(topic).Publish(ctx, &pubsub.Message{
+		Data:        body,
+		OrderingKey: event.Domain,
+	})
+	_, err = result.Get(ctx)
+	return err
+}
+
+// Close implements notifier.Publisher.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, t := range p.topics {
+		t.Stop()
+	}
+	return p.client.Close()
+}
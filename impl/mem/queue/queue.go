@@ -0,0 +1,98 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queue implements the in-memory "mem" core/queue backend, useful for
+// tests and for single-process deployments that do not need durability
+// across restarts.
+package queue
+
+import (
+	"github.com/google/keytransparency/core/queue"
+	"github.com/google/keytransparency/impl/transaction"
+
+	"golang.org/x/net/context"
+)
+
+func init() {
+	queue.Register("mem", New)
+}
+
+type mutation struct {
+	subject, payload []byte
+}
+
+// Queue is a channel-backed, process-local queue.Queue.
+type Queue struct {
+	domainID  string
+	mutations chan mutation
+	epochs    chan struct{}
+	done      chan struct{}
+}
+
+// New constructs an in-memory Queue for domainID. factory is unused; it is
+// accepted so Queue satisfies queue.Factory alongside the other backends.
+func New(ctx context.Context, domainID string, factory *transaction.Factory) (queue.Queue, error) {
+	return &Queue{
+		domainID:  domainID,
+		mutations: make(chan mutation, 1000),
+		epochs:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Enqueue adds a mutation for subject to the queue for later delivery. It
+// is not part of queue.Queue; tests type-assert New's result to *Queue to
+// feed it.
+func (q *Queue) Enqueue(subject, payload []byte) {
+	q.mutations <- mutation{subject: subject, payload: payload}
+}
+
+// AdvanceEpoch requests an epoch-advance tick on the next receive loop.
+func (q *Queue) AdvanceEpoch() {
+	select {
+	case q.epochs <- struct{}{}:
+	default:
+	}
+}
+
+type receiver struct {
+	done chan struct{}
+}
+
+func (r *receiver) Close() error {
+	close(r.done)
+	return nil
+}
+
+// StartReceiving implements queue.Queue.
+func (q *Queue) StartReceiving(procMutation queue.ProcessMutationFunc, procCreateEpoch queue.ProcessCreateEpochFunc) (queue.Receiver, error) {
+	r := &receiver{done: make(chan struct{})}
+	go func() {
+		for {
+			select {
+			case <-r.done:
+				return
+			case m := <-q.mutations:
+				if err := procMutation(m.subject, m.payload); err != nil {
+					continue
+				}
+			case <-q.epochs:
+				if err := procCreateEpoch(); err != nil {
+					continue
+				}
+			}
+		}
+	}()
+	return r, nil
+}
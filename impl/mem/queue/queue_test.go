@@ -0,0 +1,89 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestEnqueueDeliversSubjectAndPayload(t *testing.T) {
+	q, err := New(context.Background(), "domain", nil)
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	mq := q.(*Queue)
+
+	wantSubject := []byte("alice@example.com")
+	wantPayload := []byte("a serialized mutation")
+	mq.Enqueue(wantSubject, wantPayload)
+
+	gotSubject, gotPayload := make(chan []byte, 1), make(chan []byte, 1)
+	r, err := mq.StartReceiving(
+		func(subject, payload []byte) error {
+			gotSubject <- subject
+			gotPayload <- payload
+			return nil
+		},
+		func() error { return nil },
+	)
+	if err != nil {
+		t.Fatalf("StartReceiving(): %v", err)
+	}
+	defer r.Close()
+
+	select {
+	case s := <-gotSubject:
+		if !bytes.Equal(s, wantSubject) {
+			t.Errorf("subject = %q, want %q", s, wantSubject)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for procMutation")
+	}
+	if p := <-gotPayload; !bytes.Equal(p, wantPayload) {
+		t.Errorf("payload = %q, want %q", p, wantPayload)
+	}
+}
+
+func TestAdvanceEpochDeliversTick(t *testing.T) {
+	q, err := New(context.Background(), "domain", nil)
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	mq := q.(*Queue)
+
+	ticked := make(chan struct{}, 1)
+	r, err := mq.StartReceiving(
+		func(subject, payload []byte) error { return nil },
+		func() error {
+			ticked <- struct{}{}
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("StartReceiving(): %v", err)
+	}
+	defer r.Close()
+
+	mq.AdvanceEpoch()
+	select {
+	case <-ticked:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for procCreateEpoch")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package appender implements core/appender.Appender on top of GCS or S3,
+// writing each epoch's SMH or mutation batch as a single immutable object
+// keyed by its epoch number, not by a content hash: Latest needs to list
+// and order objects by epoch, which a content-addressed name could not
+// support. Writes use a generation/if-none-match precondition so that two
+// signers racing to write the same epoch cannot silently clobber each
+// other, preserving append-only semantics without a database.
+package appender
+
+import (
+	"fmt"
+
+	"github.com/google/keytransparency/core/appender"
+
+	"golang.org/x/net/context"
+)
+
+// store is the minimal object-storage operation set Appender needs. gcsStore
+// and s3Store each implement it for their respective provider.
+type store interface {
+	// putIfAbsent writes key with the given data and fails if key already
+	// exists (GCS: generation-match precondition; S3: conditional PUT).
+	putIfAbsent(ctx context.Context, key string, data []byte) error
+	// get reads the object named key.
+	get(ctx context.Context, key string) ([]byte, error)
+	// listEpochs returns every epoch number found under prefix, in
+	// ascending order.
+	listEpochs(ctx context.Context, prefix string) ([]int64, error)
+}
+
+// Appender is a core/appender.Appender backed by a GCS or S3 bucket.
+type Appender struct {
+	store  store
+	bucket string
+	prefix string
+	domain string
+}
+
+// key returns the object name for epoch within the appender's domain.
+func (a *Appender) key(epoch int64) string {
+	return fmt.Sprintf("%s/%s/%020d", a.prefix, a.domain, epoch)
+}
+
+// Append implements appender.Appender.
+func (a *Appender) Append(ctx context.Context, epoch int64, data []byte) error {
+	return a.store.putIfAbsent(ctx, a.key(epoch), data)
+}
+
+// Latest implements appender.Appender.
+func (a *Appender) Latest(ctx context.Context) (int64, []byte, error) {
+	epochs, err := a.store.listEpochs(ctx, fmt.Sprintf("%s/%s/", a.prefix, a.domain))
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(epochs) == 0 {
+		return 0, nil, nil
+	}
+	latest := epochs[len(epochs)-1]
+	data, err := a.store.get(ctx, a.key(latest))
+	if err != nil {
+		return 0, nil, err
+	}
+	return latest, data, nil
+}
+
+// NewGCS returns an Appender that writes objects to the GCS bucket
+// gs://bucket/prefix/domainID/<epoch>.
+func NewGCS(ctx context.Context, bucket, prefix, domainID string) (appender.Appender, error) {
+	s, err := newGCSStore(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &Appender{store: s, bucket: bucket, prefix: prefix, domain: domainID}, nil
+}
+
+// NewS3 returns an Appender that writes objects to s3://bucket/prefix/domainID/<epoch>.
+func NewS3(ctx context.Context, bucket, prefix, domainID string) (appender.Appender, error) {
+	s, err := newS3Store(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &Appender{store: s, bucket: bucket, prefix: prefix, domain: domainID}, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appender
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/net/context"
+	"google.golang.org/api/iterator"
+)
+
+type gcsStore struct {
+	bucket *storage.BucketHandle
+}
+
+func newGCSStore(ctx context.Context, bucket string) (*gcsStore, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient(): %v", err)
+	}
+	return &gcsStore{bucket: client.Bucket(bucket)}, nil
+}
+
+func (s *gcsStore) putIfAbsent(ctx context.Context, key string, data []byte) error {
+	// DoesNotExist requires the object to be absent, giving us the
+	// append-only precondition: a racing writer for the same epoch fails.
+	w := s.bucket.Object(key).If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("gcs: write %v: %v", key, err)
+	}
+	return w.Close()
+}
+
+func (s *gcsStore) get(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcs: read %v: %v", key, err)
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func (s *gcsStore) listEpochs(ctx context.Context, prefix string) ([]int64, error) {
+	var epochs []int64
+	it := s.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		obj, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gcs: list %v: %v", prefix, err)
+		}
+		epoch, err := strconv.ParseInt(strings.TrimPrefix(obj.Name, prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	return epochs, nil
+}
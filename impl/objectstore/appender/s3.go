@@ -0,0 +1,93 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appender
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"golang.org/x/net/context"
+)
+
+type s3Store struct {
+	bucket string
+	client *s3.S3
+}
+
+func newS3Store(ctx context.Context, bucket string) (*s3Store, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("session.NewSession(): %v", err)
+	}
+	return &s3Store{bucket: bucket, client: s3.New(sess)}, nil
+}
+
+func (s *s3Store) putIfAbsent(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		IfNoneMatch: aws.String("*"),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "PreconditionFailed" {
+		return fmt.Errorf("s3: object %v already exists", key)
+	}
+	if err != nil {
+		return fmt.Errorf("s3: put %v: %v", key, err)
+	}
+	return nil
+}
+
+func (s *s3Store) get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3: get %v: %v", key, err)
+	}
+	defer out.Body.Close()
+	return ioutil.ReadAll(out.Body)
+}
+
+func (s *s3Store) listEpochs(ctx context.Context, prefix string) ([]int64, error) {
+	var epochs []int64
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			epoch, err := strconv.ParseInt(strings.TrimPrefix(aws.StringValue(obj.Key), prefix), 10, 64)
+			if err != nil {
+				continue
+			}
+			epochs = append(epochs, epoch)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3: list %v: %v", prefix, err)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	return epochs, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queue implements the "sql" core/queue backend on top of the signer's
+// existing SQL database, so that operators who do not run etcd can still
+// deploy the signer. Leases are taken with SELECT ... FOR UPDATE SKIP
+// LOCKED, which lets multiple signer replicas poll the same table without
+// double-processing a row.
+package queue
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/keytransparency/core/queue"
+	"github.com/google/keytransparency/impl/transaction"
+
+	"golang.org/x/net/context"
+)
+
+func init() {
+	queue.Register("sql", New)
+}
+
+// pollInterval is how often a Queue with no pending work checks the table
+// again.
+const pollInterval = time.Second
+
+const (
+	kindMutation = "mutation"
+	kindEpoch    = "epoch"
+)
+
+// Queue is a SQL-table-backed queue.Queue.
+type Queue struct {
+	domainID string
+	db       *sql.DB
+}
+
+// New constructs a Queue for domainID backed by factory's SQL database. The
+// caller is responsible for having created the Queue table (see schema.sql
+// alongside this file).
+func New(ctx context.Context, domainID string, factory *transaction.Factory) (queue.Queue, error) {
+	return &Queue{
+		domainID: domainID,
+		db:       factory.DB(),
+	}, nil
+}
+
+type row struct {
+	id      int64
+	kind    string
+	subject []byte
+	payload []byte
+}
+
+// leaseOne holds a single ready row for domainID locked for the lifetime of
+// the transaction it returns, skipping rows already locked by another
+// replica. The caller must process the row and then call tx.Commit (to
+// delete it) or tx.Rollback (to release the lock and make it eligible for
+// a later retry) itself; leaseOne never deletes or commits on its own.
+func (q *Queue) leaseOne(ctx context.Context) (*sql.Tx, *row, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := &row{}
+	err = tx.QueryRowContext(ctx,
+		`SELECT Id, Kind, Subject, Payload FROM Queue WHERE DomainID = ? ORDER BY Id ASC LIMIT 1 FOR UPDATE SKIP LOCKED`,
+		q.domainID).Scan(&r.id, &r.kind, &r.subject, &r.payload)
+	switch {
+	case err == sql.ErrNoRows:
+		tx.Rollback()
+		return nil, nil, nil
+	case err != nil:
+		tx.Rollback()
+		return nil, nil, err
+	}
+	return tx, r, nil
+}
+
+type receiver struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (r *receiver) Close() error {
+	r.cancel()
+	<-r.done
+	return nil
+}
+
+// StartReceiving implements queue.Queue.
+func (q *Queue) StartReceiving(procMutation queue.ProcessMutationFunc, procCreateEpoch queue.ProcessCreateEpochFunc) (queue.Receiver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &receiver{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				q.drain(ctx, procMutation, procCreateEpoch)
+			}
+		}
+	}()
+	return r, nil
+}
+
+// drain leases and processes rows until the table is empty, a row fails to
+// process, or ctx is done. A row is only deleted after its callback
+// succeeds; a failing callback rolls back instead, leaving the row for a
+// later drain (by this replica or another) to retry.
+func (q *Queue) drain(ctx context.Context, procMutation queue.ProcessMutationFunc, procCreateEpoch queue.ProcessCreateEpochFunc) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		tx, r, err := q.leaseOne(ctx)
+		if err != nil || r == nil {
+			return
+		}
+
+		var procErr error
+		switch r.kind {
+		case kindMutation:
+			procErr = procMutation(r.subject, r.payload)
+		case kindEpoch:
+			procErr = procCreateEpoch()
+		}
+		if procErr != nil {
+			log.Printf("queue: domain %v: processing row %v (%v): %v; leaving for retry", q.domainID, r.id, r.kind, procErr)
+			tx.Rollback()
+			return
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM Queue WHERE Id = ?`, r.id); err != nil {
+			log.Printf("queue: domain %v: deleting row %v: %v", q.domainID, r.id, err)
+			tx.Rollback()
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			log.Printf("queue: domain %v: committing row %v: %v", q.domainID, r.id, err)
+			return
+		}
+	}
+}
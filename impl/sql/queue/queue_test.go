@@ -0,0 +1,82 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"golang.org/x/net/context"
+)
+
+const leaseQuery = `SELECT Id, Kind, Subject, Payload FROM Queue WHERE DomainID = ? ORDER BY Id ASC LIMIT 1 FOR UPDATE SKIP LOCKED`
+
+func TestDrainDeletesRowOnSuccess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New(): %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(leaseQuery)).WithArgs("domain").
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "Kind", "Subject", "Payload"}).
+			AddRow(1, kindMutation, []byte("subject"), []byte("payload")))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM Queue WHERE Id = ?`)).WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(leaseQuery)).WithArgs("domain").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	q := &Queue{domainID: "domain", db: db}
+	q.drain(context.Background(),
+		func(subject, payload []byte) error { return nil },
+		func() error { return nil },
+	)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDrainLeavesRowOnFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New(): %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(leaseQuery)).WithArgs("domain").
+		WillReturnRows(sqlmock.NewRows([]string{"Id", "Kind", "Subject", "Payload"}).
+			AddRow(1, kindMutation, []byte("subject"), []byte("payload")))
+	mock.ExpectRollback()
+
+	q := &Queue{domainID: "domain", db: db}
+	q.drain(context.Background(),
+		func(subject, payload []byte) error { return errors.New("processing failed") },
+		func() error { return nil },
+	)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}